@@ -0,0 +1,94 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gofmt
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// replay applies ops to a and checks that it reproduces b, which is what
+// computeEdits and any editor consuming its output both rely on.
+func replay(t *testing.T, a, b []byte, ops []diffOp) {
+	t.Helper()
+	var got []byte
+	for _, op := range ops {
+		switch op.kind {
+		case opKeep:
+			got = append(got, a[op.aIdx])
+		case opInsert:
+			got = append(got, b[op.bIdx])
+		case opDelete:
+			// consumed from a, contributes nothing to b
+		}
+	}
+	if !bytes.Equal(got, b) {
+		t.Errorf("replaying ops gives %q, want %q", got, b)
+	}
+}
+
+func TestMyersDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{"both empty", "", ""},
+		{"pure insert into empty", "", "hello"},
+		{"pure delete to empty", "hello", ""},
+		{"identical", "hello world", "hello world"},
+		{"insert at EOF", "hello", "hello, world"},
+		{"multi-byte run replaced", "hello brave world", "hello new world"},
+		{"insert at start", "world", "hello world"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops := myersDiff([]byte(tt.a), []byte(tt.b))
+			replay(t, []byte(tt.a), []byte(tt.b), ops)
+		})
+	}
+}
+
+func TestComputeEditsEmptyDiff(t *testing.T) {
+	src := []byte("package p\n")
+	if edits := computeEdits(src, src); edits != nil {
+		t.Errorf("computeEdits(src, src) = %v, want nil", edits)
+	}
+}
+
+func TestComputeEditsInsertAtEOF(t *testing.T) {
+	src := []byte("package p\n")
+	res := []byte("package p\n\nvar x int\n")
+
+	edits := computeEdits(src, res)
+	want := []jsonEdit{
+		{
+			Start:   jsonPos{Line: 2, Col: 1, Offset: len(src)},
+			End:     jsonPos{Line: 2, Col: 1, Offset: len(src)},
+			NewText: "\nvar x int\n",
+		},
+	}
+	if !reflect.DeepEqual(edits, want) {
+		t.Errorf("computeEdits = %+v, want %+v", edits, want)
+	}
+}
+
+func TestComputeEditsLineColTracking(t *testing.T) {
+	src := []byte("line1\nline2\nline3\n")
+	res := []byte("line1\nLINE2\nline3\n")
+
+	edits := computeEdits(src, res)
+	if len(edits) != 1 {
+		t.Fatalf("computeEdits returned %d edits, want 1: %+v", len(edits), edits)
+	}
+	e := edits[0]
+	if e.Start.Line != 2 || e.Start.Col != 1 {
+		t.Errorf("edit start = %+v, want line 2 col 1", e.Start)
+	}
+	if e.NewText != "LINE" {
+		t.Errorf("edit NewText = %q, want %q", e.NewText, "LINE")
+	}
+}
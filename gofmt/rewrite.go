@@ -0,0 +1,280 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gofmt
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+)
+
+// parseRewriteRule splits a "pattern -> replacement" rule of the form
+// accepted by -r and parses both sides as Go expressions.
+func parseRewriteRule(rule string) (pattern, replacement ast.Expr, err error) {
+	parts := strings.SplitN(rule, "->", 2)
+	if len(parts) != 2 {
+		return nil, nil, fmt.Errorf("rewrite rule must be of the form 'pattern -> replacement'")
+	}
+	pattern, err = parseExpr(parts[0], "pattern")
+	if err != nil {
+		return nil, nil, err
+	}
+	replacement, err = parseExpr(parts[1], "replacement")
+	if err != nil {
+		return nil, nil, err
+	}
+	return pattern, replacement, nil
+}
+
+func parseExpr(s, what string) (ast.Expr, error) {
+	x, err := parser.ParseExpr(s)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s %s at %s", what, s, err)
+	}
+	return x, nil
+}
+
+// rewriteFile applies the pattern -> replacement rewrite to every matching
+// subtree of f and reports whether anything changed.
+func rewriteFile(pattern, replacement ast.Expr, f *ast.File) bool {
+	cmd := &rewriter{
+		pattern:     reflect.ValueOf(pattern),
+		replacement: reflect.ValueOf(replacement),
+		changed:     false,
+	}
+	var rewriteVal func(val reflect.Value) reflect.Value
+	rewriteVal = func(val reflect.Value) reflect.Value {
+		val = apply(rewriteVal, val)
+		for {
+			vars := make(map[string]reflect.Value)
+			if match(vars, cmd.pattern, val) {
+				val = subst(vars, cmd.replacement, matchedPos(val))
+				cmd.changed = true
+				continue
+			}
+			return val
+		}
+	}
+	rewriteVal(reflect.ValueOf(f))
+	return cmd.changed
+}
+
+// matchedPos returns the position of the node val holds, so that a
+// replacement substituted in its place (see subst) keeps the original
+// node's location instead of printing at token.NoPos.
+func matchedPos(val reflect.Value) reflect.Value {
+	if !val.IsValid() || !val.CanInterface() {
+		return reflect.Value{}
+	}
+	n, ok := val.Interface().(ast.Node)
+	if !ok || n == nil {
+		return reflect.Value{}
+	}
+	return reflect.ValueOf(n.Pos())
+}
+
+type rewriter struct {
+	pattern, replacement reflect.Value
+	changed              bool
+}
+
+// set is a wrapper for x.Set(y); it protects the caller from panics if x
+// cannot be changed to y.
+func set(x, y reflect.Value) {
+	// don't bother if y is invalid.
+	if !y.IsValid() {
+		return
+	}
+	defer func() {
+		if x := recover(); x != nil {
+			if s, ok := x.(string); ok &&
+				(strings.Contains(s, "type mismatch") || strings.Contains(s, "not assignable")) {
+				// ignore type mismatches caused by wildcard capture
+				return
+			}
+			panic(x)
+		}
+	}()
+	x.Set(y)
+}
+
+// Types/values for special cases.
+var (
+	objectPtrNil = reflect.ValueOf((*ast.Object)(nil))
+	scopePtrNil  = reflect.ValueOf((*ast.Scope)(nil))
+
+	identType     = reflect.TypeOf((*ast.Ident)(nil))
+	objectPtrType = reflect.TypeOf((*ast.Object)(nil))
+	scopePtrType  = reflect.TypeOf((*ast.Scope)(nil))
+	positionType  = reflect.TypeOf(token.NoPos)
+)
+
+// apply replaces each AST field x in val with f(x), operating depth-first.
+func apply(f func(reflect.Value) reflect.Value, val reflect.Value) reflect.Value {
+	if !val.IsValid() {
+		return reflect.Value{}
+	}
+
+	// *ast.Object and *ast.Scope form reference cycles back through the
+	// nodes that declare them (e.g. an *ast.Ident's Obj.Decl can point back
+	// to that same identifier), and are stale after a rewrite anyway; don't
+	// follow them, just drop them.
+	if val.Type() == objectPtrType {
+		return objectPtrNil
+	}
+	if val.Type() == scopePtrType {
+		return scopePtrNil
+	}
+
+	switch v := val; v.Kind() {
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			e := v.Index(i)
+			set(e, f(e))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			e := v.Field(i)
+			set(e, f(e))
+		}
+	case reflect.Interface:
+		e := v.Elem()
+		set(v, f(e))
+	case reflect.Ptr:
+		if !v.IsNil() {
+			e := v.Elem()
+			set(e, f(e))
+		}
+	}
+	return val
+}
+
+// match reports whether pattern matches val, recording wildcard bindings
+// (single-letter lowercase identifiers, see isWildcard) into vars.
+func match(vars map[string]reflect.Value, pattern, val reflect.Value) bool {
+	// Wildcard matches any expression. If it appears multiple times in the
+	// pattern, it must match the same expression each time.
+	if vars != nil && pattern.IsValid() && pattern.Type() == identType {
+		if ident, ok := pattern.Interface().(*ast.Ident); ok && isWildcard(ident.Name) {
+			if old, ok := vars[ident.Name]; ok {
+				return match(nil, old, val)
+			}
+			vars[ident.Name] = val
+			return true
+		}
+	}
+
+	if !pattern.IsValid() || !val.IsValid() {
+		return !pattern.IsValid() && !val.IsValid()
+	}
+
+	if pattern.Type() != val.Type() {
+		return false
+	}
+
+	// Special cases.
+	switch pattern.Type() {
+	case identType:
+		// For identifiers, only the names need to match
+		// (and none of the other *ast.Object information).
+		p := pattern.Interface().(*ast.Ident)
+		v := val.Interface().(*ast.Ident)
+		if p == nil || v == nil {
+			return p == v
+		}
+		return p.Name == v.Name
+	case objectPtrType, positionType:
+		// object pointers and token positions don't matter for matching
+		return true
+	}
+
+	p := reflect.Indirect(pattern)
+	v := reflect.Indirect(val)
+	if !p.IsValid() || !v.IsValid() {
+		return !p.IsValid() && !v.IsValid()
+	}
+
+	switch p.Kind() {
+	case reflect.Slice:
+		if p.Len() != v.Len() {
+			return false
+		}
+		for i := 0; i < p.Len(); i++ {
+			if !match(vars, p.Index(i), v.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for i := 0; i < p.NumField(); i++ {
+			if !match(vars, p.Field(i), v.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Interface:
+		return match(vars, p.Elem(), v.Elem())
+	}
+
+	// Fall back to normal equality for basic types (strings, ints, etc).
+	return p.Kind() != reflect.Invalid && reflect.DeepEqual(p.Interface(), v.Interface())
+}
+
+func isWildcard(name string) bool {
+	// Single-letter lowercase identifiers (other than "_") act as
+	// wildcards, the same convention upstream gofmt -r uses.
+	return len(name) == 1 && strings.ToLower(name) == name && name != "_" && name >= "a" && name <= "z"
+}
+
+// subst returns a copy of pattern with the recorded wildcard bindings
+// substituted in, and position information taken from pos (if valid).
+func subst(vars map[string]reflect.Value, pattern reflect.Value, pos reflect.Value) reflect.Value {
+	if !pattern.IsValid() {
+		return reflect.Value{}
+	}
+
+	// Wildcard gets replaced with the value it was bound to.
+	if ident, ok := pattern.Interface().(*ast.Ident); ok && isWildcard(ident.Name) {
+		if v, ok := vars[ident.Name]; ok {
+			return v
+		}
+	}
+
+	if pos.IsValid() && pattern.Type() == positionType {
+		return pos
+	}
+
+	switch pattern.Kind() {
+	case reflect.Slice:
+		v := reflect.MakeSlice(pattern.Type(), pattern.Len(), pattern.Len())
+		for i := 0; i < pattern.Len(); i++ {
+			v.Index(i).Set(subst(vars, pattern.Index(i), pos))
+		}
+		return v
+	case reflect.Struct:
+		v := reflect.New(pattern.Type()).Elem()
+		for i := 0; i < pattern.NumField(); i++ {
+			set(v.Field(i), subst(vars, pattern.Field(i), pos))
+		}
+		return v
+	case reflect.Ptr:
+		v := reflect.New(pattern.Type().Elem())
+		if !pattern.IsNil() {
+			set(v.Elem(), subst(vars, pattern.Elem(), pos))
+		}
+		return v
+	case reflect.Interface:
+		v := reflect.New(pattern.Type()).Elem()
+		if !pattern.IsNil() {
+			set(v, subst(vars, pattern.Elem(), pos))
+		}
+		return v
+	}
+
+	return pattern
+}
@@ -0,0 +1,92 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gofmt
+
+import (
+	"bytes"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestRewriteFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   string
+		src    string
+		want   string
+		change bool
+	}{
+		{
+			name:   "simple slice rewrite",
+			rule:   "a[b:len(a)] -> a[b:]",
+			src:    "package p\n\nvar _ = s[i:len(s)]\n",
+			want:   "package p\n\nvar _ = s[i:]\n",
+			change: true,
+		},
+		{
+			name:   "wildcard reused must match same expression",
+			rule:   "append(a, a...) -> a",
+			src:    "package p\n\nfunc f() { x = append(x, x...); y = append(x, y...) }\n",
+			want:   "package p\n\nfunc f() { x = x; y = append(x, y...) }\n",
+			change: true,
+		},
+		{
+			name:   "no match leaves source untouched",
+			rule:   "a[b:len(a)] -> a[b:]",
+			src:    "package p\n\nvar _ = s[1:2]\n",
+			want:   "package p\n\nvar _ = s[1:2]\n",
+			change: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern, replacement, err := parseRewriteRule(tt.rule)
+			if err != nil {
+				t.Fatalf("parseRewriteRule(%q): %v", tt.rule, err)
+			}
+
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "", tt.src, 0)
+			if err != nil {
+				t.Fatalf("ParseFile: %v", err)
+			}
+
+			changed := rewriteFile(pattern, replacement, file)
+			if changed != tt.change {
+				t.Errorf("rewriteFile changed = %v, want %v", changed, tt.change)
+			}
+
+			var buf bytes.Buffer
+			if err := format.Node(&buf, fset, file); err != nil {
+				t.Fatalf("format.Node: %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("rewriteFile output =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsWildcard(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"a", true},
+		{"z", true},
+		{"_", false},
+		{"ab", false},
+		{"A", false},
+		{"x1", false},
+	}
+	for _, tt := range tests {
+		if got := isWildcard(tt.name); got != tt.want {
+			t.Errorf("isWildcard(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gofmt
+
+import (
+	"bytes"
+	"go/ast"
+	"go/printer"
+	"go/token"
+)
+
+// simplify rewrites f in place, applying the same AST-level simplifications
+// that upstream `gofmt -s` performs: composite literal elision, slice
+// expression simplification (a[b:len(a)] -> a[b:]), and range-clause
+// simplification (for x, _ := range v -> for x := range v).
+func simplify(f *ast.File) {
+	var s simplifier
+	ast.Walk(s, f)
+}
+
+type simplifier struct{}
+
+func (s simplifier) Visit(node ast.Node) ast.Visitor {
+	switch n := node.(type) {
+	case *ast.CompositeLit:
+		simplifyCompositeLit(n)
+
+	case *ast.SliceExpr:
+		// a[b:len(a)] -> a[b:]
+		if n.Max != nil || n.High == nil {
+			break
+		}
+		ident, ok := n.X.(*ast.Ident)
+		if !ok {
+			break
+		}
+		call, ok := n.High.(*ast.CallExpr)
+		if !ok || len(call.Args) != 1 || call.Ellipsis != token.NoPos {
+			break
+		}
+		fun, ok := call.Fun.(*ast.Ident)
+		if !ok || fun.Name != "len" {
+			break
+		}
+		arg, ok := call.Args[0].(*ast.Ident)
+		if !ok || arg.Name != ident.Name {
+			break
+		}
+		n.High = nil
+
+	case *ast.RangeStmt:
+		// for x, _ := range v {...} -> for x := range v {...}
+		if isBlank(n.Value) {
+			n.Value = nil
+		}
+		// for _ := range v {...} -> for range v {...}
+		if isBlank(n.Key) && n.Value == nil {
+			n.Key = nil
+		}
+	}
+	return s
+}
+
+// simplifyCompositeLit elides the redundant element type in composite
+// literal elements whose type matches the enclosing array/slice/map literal
+// (e.g. []T{T{1}, T{2}} -> []T{{1}, {2}}).
+func simplifyCompositeLit(outer *ast.CompositeLit) {
+	var eltType ast.Expr
+	switch typ := outer.Type.(type) {
+	case *ast.ArrayType:
+		eltType = typ.Elt
+	case *ast.MapType:
+		eltType = typ.Value
+	default:
+		return
+	}
+	if eltType == nil {
+		return
+	}
+	for _, x := range outer.Elts {
+		if kv, ok := x.(*ast.KeyValueExpr); ok {
+			x = kv.Value
+		}
+		if elt, ok := x.(*ast.CompositeLit); ok && elt.Type != nil && exprEqual(elt.Type, eltType) {
+			elt.Type = nil
+		}
+	}
+}
+
+func isBlank(p ast.Expr) bool {
+	ident, ok := p.(*ast.Ident)
+	return ok && ident.Name == "_"
+}
+
+// exprEqual reports whether two type expressions print identically, which
+// is sufficient for deciding whether a composite literal's element type is
+// redundant with its enclosing literal's element type.
+func exprEqual(a, b ast.Expr) bool {
+	var bufA, bufB bytes.Buffer
+	fset := token.NewFileSet()
+	if printer.Fprint(&bufA, fset, a) != nil {
+		return false
+	}
+	if printer.Fprint(&bufB, fset, b) != nil {
+		return false
+	}
+	return bufA.String() == bufB.String()
+}
@@ -0,0 +1,38 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gofix
+
+import (
+	"go/ast"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+func init() {
+	register(&Fix{
+		Name:     "any",
+		Date:     "2022-03-01",
+		F:        fixAny,
+		Desc:     `Rewrites the empty interface type interface{} to the any alias introduced in Go 1.18.`,
+		Disabled: true, // purely cosmetic; off by default, enable with -force any
+	})
+}
+
+// fixAny rewrites every bare interface{} type expression to any, wherever it
+// appears: a field type, a value spec, or nested inside another type such as
+// map[string]interface{} or []interface{}.
+func fixAny(f *ast.File) bool {
+	fixed := false
+	astutil.Apply(f, nil, func(c *astutil.Cursor) bool {
+		it, ok := c.Node().(*ast.InterfaceType)
+		if !ok || len(it.Methods.List) != 0 {
+			return true
+		}
+		c.Replace(ast.NewIdent("any"))
+		fixed = true
+		return true
+	})
+	return fixed
+}
@@ -0,0 +1,27 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gofix
+
+import "go/ast"
+
+func init() {
+	register(&Fix{
+		Name: "context",
+		Date: "2016-09-01",
+		F:    fixContextImport,
+		Desc: `Rewrites imports of the old golang.org/x/net/context to the standard
+library's context package, which has been a superset of it since Go 1.7.`,
+	})
+}
+
+const oldContextPath = "golang.org/x/net/context"
+const newContextPath = "context"
+
+func fixContextImport(f *ast.File) bool {
+	if !usesImport(f, oldContextPath) {
+		return false
+	}
+	return rewriteImport(f, oldContextPath, newContextPath)
+}
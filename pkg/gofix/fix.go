@@ -0,0 +1,233 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package gofix implements a `go tool fix`-style migration subsystem: a
+// registry of named, dated AST rewrites that can be listed, restricted, and
+// applied to a tree of Go source files.
+package gofix
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/visualfc/gotools/pkg/command"
+	"github.com/visualfc/gotools/pkg/godiff"
+)
+
+var Command = &command.Command{
+	Run:       runFix,
+	UsageLine: "fix [flags] [path ...]",
+	Short:     "fix finds Go programs that use old APIs and rewrites them to use newer ones",
+	Long: `fix finds Go programs that use old APIs and rewrites them to use newer ones.
+
+Each fix has a name and a date, and is applied in date order until the file
+reaches a fixed point. Use -r to run only a subset of fixes, -force to run
+fixes that are disabled by default, and -diff to preview changes without
+writing them.`,
+}
+
+// Fix describes a single named rewrite over a parsed Go file. F reports
+// whether it changed the file; runFix re-applies every enabled fix until
+// none of them report a change.
+type Fix struct {
+	Name     string
+	Date     string // YYYY-MM-DD, used to order fixes
+	F        func(*ast.File) bool
+	Desc     string
+	Disabled bool // disabled by default; must be named explicitly via -force
+}
+
+var fileSet = token.NewFileSet() // shared so astutil import helpers can edit positions consistently
+
+var fixes []*Fix
+
+func register(f *Fix) {
+	fixes = append(fixes, f)
+}
+
+var (
+	fixRestrict string
+	fixForce    string
+	fixDiff     bool
+	fixList     bool
+)
+
+func init() {
+	Command.Flag.StringVar(
+		&fixRestrict,
+		"r",
+		"",
+		"comma-separated list of fixes to run (default: all enabled fixes)",
+	)
+	Command.Flag.StringVar(
+		&fixForce,
+		"force",
+		"",
+		"comma-separated list of disabled fixes to force on",
+	)
+	Command.Flag.BoolVar(&fixDiff, "diff", false, "print diffs instead of writing files")
+	Command.Flag.BoolVar(&fixList, "l", false, "list all registered fixes and exit")
+}
+
+func runFix(cmd *command.Command, args []string) error {
+	sort.Slice(fixes, func(i, j int) bool { return fixes[i].Date < fixes[j].Date })
+
+	if fixList {
+		for _, f := range fixes {
+			state := "enabled"
+			if f.Disabled {
+				state = "disabled"
+			}
+			fmt.Fprintf(cmd.Stdout, "%s\t%s\t%s\t%s\n", f.Name, f.Date, state, f.Desc)
+		}
+		return nil
+	}
+
+	enabled, err := enabledFixes()
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("fix: no files given")
+	}
+
+	var lastErr error
+	for _, path := range args {
+		switch dir, err := os.Stat(path); {
+		case err != nil:
+			fmt.Fprintln(cmd.Stderr, err)
+			lastErr = err
+		case dir.IsDir():
+			err = filepath.Walk(path, func(p string, f os.FileInfo, err error) error {
+				if err != nil || f.IsDir() || !strings.HasSuffix(p, ".go") {
+					return err
+				}
+				if err := fixFile(p, enabled); err != nil {
+					fmt.Fprintln(cmd.Stderr, err)
+					lastErr = err
+				}
+				return nil
+			})
+			if err != nil {
+				lastErr = err
+			}
+		default:
+			if err := fixFile(path, enabled); err != nil {
+				fmt.Fprintln(cmd.Stderr, err)
+				lastErr = err
+			}
+		}
+	}
+	return lastErr
+}
+
+func enabledFixes() ([]*Fix, error) {
+	restrict := splitList(fixRestrict)
+	force := splitList(fixForce)
+	for _, name := range restrict {
+		if findFix(name) == nil {
+			return nil, fmt.Errorf("fix: unknown fix %q", name)
+		}
+	}
+	for _, name := range force {
+		if findFix(name) == nil {
+			return nil, fmt.Errorf("fix: unknown fix %q", name)
+		}
+	}
+
+	var out []*Fix
+	for _, f := range fixes {
+		if len(restrict) > 0 && !contains(restrict, f.Name) {
+			continue
+		}
+		if f.Disabled && !contains(force, f.Name) {
+			continue
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+func findFix(name string) *Fix {
+	for _, f := range fixes {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// fixFile parses filename, applies every fix in enabled until a fixed
+// point is reached, and (unless -diff is set) rewrites the file only if
+// something changed.
+func fixFile(filename string, enabled []*Fix) error {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	file, err := parser.ParseFile(fileSet, filename, src, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for {
+		fixedThisRound := false
+		for _, f := range enabled {
+			if f.F(file) {
+				fixedThisRound = true
+				changed = true
+			}
+		}
+		if !fixedThisRound {
+			break
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fileSet, file); err != nil {
+		return err
+	}
+
+	if fixDiff {
+		diff, err := godiff.UnifiedDiffString(string(src), buf.String())
+		if err != nil {
+			return fmt.Errorf("computing diff: %s", err)
+		}
+		fmt.Printf("diff %s\n", filename)
+		fmt.Print(diff)
+		return nil
+	}
+
+	return ioutil.WriteFile(filename, buf.Bytes(), 0)
+}
@@ -0,0 +1,258 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gofix
+
+import (
+	"bytes"
+	"go/format"
+	"go/parser"
+	"testing"
+)
+
+// applyFix parses src, runs the named fix's F once, and returns the
+// formatted result along with whether F reported a change.
+func applyFix(t *testing.T, name, src string) (string, bool) {
+	t.Helper()
+	f := findFix(name)
+	if f == nil {
+		t.Fatalf("no registered fix named %q", name)
+	}
+
+	file, err := parser.ParseFile(fileSet, "", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+
+	changed := f.F(file)
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fileSet, file); err != nil {
+		t.Fatalf("format.Node: %v", err)
+	}
+	return buf.String(), changed
+}
+
+func TestFixContextImport(t *testing.T) {
+	src := `package p
+
+import "golang.org/x/net/context"
+
+var _ = context.Background
+`
+	want := `package p
+
+import "context"
+
+var _ = context.Background
+`
+	got, changed := applyFix(t, "context", src)
+	if !changed {
+		t.Fatal("fixContextImport reported no change")
+	}
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFixContextImportNoOldImport(t *testing.T) {
+	src := `package p
+
+import "context"
+
+var _ = context.Background
+`
+	_, changed := applyFix(t, "context", src)
+	if changed {
+		t.Error("fixContextImport reported a change with no old import present")
+	}
+}
+
+func TestFixIoutil(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    string
+		changed bool
+	}{
+		{
+			name: "fully migrated selectors drop the import",
+			src: `package p
+
+import "io/ioutil"
+
+var _ = ioutil.ReadAll
+var _ = ioutil.TempFile
+var _ = ioutil.TempDir
+`,
+			want: `package p
+
+import (
+	"io"
+	"os"
+)
+
+var _ = io.ReadAll
+var _ = os.CreateTemp
+var _ = os.MkdirTemp
+`,
+			changed: true,
+		},
+		{
+			name: "unmapped selector keeps the import",
+			src: `package p
+
+import "io/ioutil"
+
+var _ = ioutil.ReadAll
+var _ = ioutil.ReadDir
+`,
+			want: `package p
+
+import (
+	"io"
+	"io/ioutil"
+)
+
+var _ = io.ReadAll
+var _ = ioutil.ReadDir
+`,
+			changed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := applyFix(t, "ioutil", tt.src)
+			if changed != tt.changed {
+				t.Errorf("changed = %v, want %v", changed, tt.changed)
+			}
+			if got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFixAnyNested(t *testing.T) {
+	src := `package p
+
+var m map[string]interface{}
+var s []interface{}
+var x interface{}
+`
+	want := `package p
+
+var m map[string]any
+var s []any
+var x any
+`
+	got, changed := applyFix(t, "any", src)
+	if !changed {
+		t.Fatal("fixAny reported no change")
+	}
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFixAnyPreservesNonEmptyInterface(t *testing.T) {
+	src := `package p
+
+var x interface{ Foo() }
+`
+	got, changed := applyFix(t, "any", src)
+	if changed {
+		t.Error("fixAny reported a change for a non-empty interface")
+	}
+	if got != src {
+		t.Errorf("got:\n%s\nwant unchanged:\n%s", got, src)
+	}
+}
+
+func TestFixRandV2SkipsWhenSeeded(t *testing.T) {
+	src := `package p
+
+import "math/rand"
+
+func f() {
+	rand.Seed(1)
+}
+`
+	_, changed := applyFix(t, "randv2", src)
+	if changed {
+		t.Error("fixRandV2 reported a change in a file that calls rand.Seed")
+	}
+}
+
+func TestFixRandV2RewritesImport(t *testing.T) {
+	src := `package p
+
+import "math/rand"
+
+var _ = rand.Intn
+`
+	want := `package p
+
+import "math/rand/v2"
+
+var _ = rand.Intn
+`
+	got, changed := applyFix(t, "randv2", src)
+	if !changed {
+		t.Fatal("fixRandV2 reported no change")
+	}
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestEnabledFixesExcludesDisabledByDefault(t *testing.T) {
+	savedRestrict, savedForce := fixRestrict, fixForce
+	defer func() { fixRestrict, fixForce = savedRestrict, savedForce }()
+	fixRestrict, fixForce = "", ""
+
+	enabled, err := enabledFixes()
+	if err != nil {
+		t.Fatalf("enabledFixes: %v", err)
+	}
+	for _, f := range enabled {
+		if f.Name == "randv2" {
+			t.Error("enabledFixes included randv2, which is disabled by default")
+		}
+	}
+}
+
+func TestEnabledFixesForce(t *testing.T) {
+	savedRestrict, savedForce := fixRestrict, fixForce
+	defer func() { fixRestrict, fixForce = savedRestrict, savedForce }()
+	fixRestrict, fixForce = "", "randv2"
+
+	enabled, err := enabledFixes()
+	if err != nil {
+		t.Fatalf("enabledFixes: %v", err)
+	}
+	if findFixIn(enabled, "randv2") == nil {
+		t.Error("enabledFixes did not include randv2 despite -force randv2")
+	}
+}
+
+func TestEnabledFixesUnknownName(t *testing.T) {
+	savedRestrict, savedForce := fixRestrict, fixForce
+	defer func() { fixRestrict, fixForce = savedRestrict, savedForce }()
+	fixRestrict, fixForce = "nope", ""
+
+	if _, err := enabledFixes(); err == nil {
+		t.Error("enabledFixes did not error on an unknown fix name")
+	}
+}
+
+func findFixIn(fixes []*Fix, name string) *Fix {
+	for _, f := range fixes {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gofix
+
+import (
+	"go/ast"
+	"strconv"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// isPkgDot reports whether expr is a selector "pkg.name" whose operand is
+// the identifier pkg. It does not check that pkg names an import.
+func isPkgDot(expr ast.Expr, pkg, name string) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != name {
+		return false
+	}
+	id, ok := sel.X.(*ast.Ident)
+	return ok && id.Name == pkg
+}
+
+// importPath returns the string value of spec's import path.
+func importPath(spec *ast.ImportSpec) string {
+	path, err := strconv.Unquote(spec.Path.Value)
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// usesImport reports whether f imports path.
+func usesImport(f *ast.File, path string) bool {
+	for _, spec := range f.Imports {
+		if importPath(spec) == path {
+			return true
+		}
+	}
+	return false
+}
+
+// addImport adds an unnamed import of path to f if it is not already
+// imported.
+func addImport(f *ast.File, path string) {
+	if usesImport(f, path) {
+		return
+	}
+	astutil.AddImport(fileSet, f, path)
+}
+
+// deleteImport removes any import of path from f.
+func deleteImport(f *ast.File, path string) bool {
+	return astutil.DeleteImport(fileSet, f, path)
+}
+
+// rewriteImport renames an existing import of oldPath to newPath.
+func rewriteImport(f *ast.File, oldPath, newPath string) bool {
+	return astutil.RewriteImport(fileSet, f, oldPath, newPath)
+}
@@ -0,0 +1,75 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gofix
+
+import "go/ast"
+
+func init() {
+	register(&Fix{
+		Name: "ioutil",
+		Date: "2021-08-01",
+		F:    fixIoutil,
+		Desc: `Rewrites io/ioutil calls to their io and os replacements
+(ioutil.ReadAll -> io.ReadAll, ioutil.ReadFile -> os.ReadFile, and so on),
+following the deprecation of io/ioutil in Go 1.16.`,
+	})
+}
+
+// ioutilMoves maps an ioutil.X selector to the package and function it was
+// replaced by. ReadDir is deliberately omitted: os.ReadDir returns
+// []fs.DirEntry rather than ioutil.ReadDir's []fs.FileInfo, so renaming the
+// selector alone would produce code that fails to compile wherever the
+// result is used (.Size(), .Mode(), etc.) — the same reason upstream `go
+// fix` doesn't auto-migrate it either.
+var ioutilMoves = map[string]struct{ pkg, name string }{
+	"ReadAll":   {"io", "ReadAll"},
+	"NopCloser": {"io", "NopCloser"},
+	"Discard":   {"io", "Discard"},
+	"ReadFile":  {"os", "ReadFile"},
+	"WriteFile": {"os", "WriteFile"},
+	"TempDir":   {"os", "MkdirTemp"},
+	"TempFile":  {"os", "CreateTemp"},
+}
+
+func fixIoutil(f *ast.File) bool {
+	if !usesImport(f, "io/ioutil") {
+		return false
+	}
+
+	fixed := false
+	remaining := false // an ioutil.X selector with no entry in ioutilMoves survived
+	usedPkgs := map[string]bool{}
+	ast.Inspect(f, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		id, ok := sel.X.(*ast.Ident)
+		if !ok || id.Name != "ioutil" {
+			return true
+		}
+		to, ok := ioutilMoves[sel.Sel.Name]
+		if !ok {
+			remaining = true
+			return true
+		}
+		id.Name = to.pkg
+		sel.Sel.Name = to.name
+		usedPkgs[to.pkg] = true
+		fixed = true
+		return true
+	})
+	if !fixed {
+		return false
+	}
+
+	for pkg := range usedPkgs {
+		addImport(f, pkg)
+	}
+	if !remaining {
+		deleteImport(f, "io/ioutil")
+	}
+	return true
+}